@@ -0,0 +1,51 @@
+package main
+
+import "io"
+
+// Пакетные функции ниже — тонкие обертки над DefaultLedger, сохраненные
+// для обратной совместимости с кодом, написанным до введения типа Ledger.
+// Новый код должен создавать свой *Ledger через NewLedger/Reopen.
+
+func AddTransaction(tx Transaction) (int, error) {
+	return DefaultLedger.AddTransaction(tx)
+}
+
+func SetBudget(b Budget) error {
+	return DefaultLedger.SetBudget(b)
+}
+
+func GetBudget(category string) (Budget, bool) {
+	return DefaultLedger.GetBudget(category)
+}
+
+func ListBudgets() map[string]Budget {
+	return DefaultLedger.ListBudgets()
+}
+
+func LoadBudgets(r io.Reader) error {
+	return DefaultLedger.LoadBudgets(r)
+}
+
+func GetCategoryTotal(category string) float64 {
+	return DefaultLedger.GetCategoryTotal(category)
+}
+
+func GetAccountBalance(account, asset string) int64 {
+	return DefaultLedger.GetAccountBalance(account, asset)
+}
+
+func ListPostings(account string) []Posting {
+	return DefaultLedger.ListPostings(account)
+}
+
+func ListTransactions() []Transaction {
+	return DefaultLedger.ListTransactions()
+}
+
+func GetCategoryTotalIn(category, asset string) (int64, error) {
+	return DefaultLedger.GetCategoryTotalIn(category, asset)
+}
+
+func ListTransactionsConverted(asset string) ([]ConvertedTransaction, error) {
+	return DefaultLedger.ListTransactionsConverted(asset)
+}
@@ -0,0 +1,180 @@
+// Серверные маршруты API по-прежнему ведутся вручную (см. пояснение в
+// pkg/client/client.go про go:generate и отсутствие подключенной зависимости
+// oapi-codegen) — ниже зафиксирована команда для генерации типов запросов и
+// ответов из той же схемы, которую Server реализует сам.
+//
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen -generate types -package main -o types.gen.go openapi.yaml
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// apiResponse — форма структурированной ошибки/успеха, которую отдает API.
+// Она же описана в схемах ошибок openapi.yaml.
+type apiResponse struct {
+	Success bool        `json:"success"`
+	Code    string      `json:"code,omitempty"`
+	Error   string      `json:"error,omitempty"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// Server реализует HTTP/JSON API поверх Ledger по спецификации openapi.yaml.
+type Server struct {
+	ledger *Ledger
+}
+
+// NewServer создает Server, обслуживающий запросы над переданной учетной книгой.
+func NewServer(l *Ledger) *Server {
+	return &Server{ledger: l}
+}
+
+// Routes возвращает http.Handler со всеми маршрутами API.
+func (s *Server) Routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transactions", s.handleTransactions)
+	mux.HandleFunc("/budgets", s.handleBudgets)
+	mux.HandleFunc("/budgets/", s.handleBudgetByCategory)
+	mux.HandleFunc("/accounts/", s.handleAccountBalance)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, apiResponse{Success: false, Code: code, Error: message})
+}
+
+func (s *Server) handleTransactions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var tx Transaction
+		if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_BODY", err.Error())
+			return
+		}
+
+		id, err := s.ledger.AddTransaction(tx)
+		if err != nil {
+			s.writeTransactionError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, apiResponse{Success: true, Data: map[string]int{"id": id}})
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, apiResponse{Success: true, Data: s.ledger.ListTransactions()})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "метод не поддерживается")
+	}
+}
+
+// writeTransactionError сопоставляет ошибки AddTransaction с HTTP-статусами
+// и машиночитаемыми кодами.
+func (s *Server) writeTransactionError(w http.ResponseWriter, err error) {
+	var budgetErr *BudgetExceededError
+	if errors.As(err, &budgetErr) {
+		writeJSON(w, http.StatusUnprocessableEntity, apiResponse{
+			Success: false,
+			Code:    "BUDGET_EXCEEDED",
+			Error:   budgetErr.Error(),
+			Data: map[string]int64{
+				"current":   budgetErr.Current,
+				"attempted": budgetErr.Attempted,
+				"limit":     budgetErr.Limit,
+			},
+		})
+		return
+	}
+	if errors.Is(err, ErrInsufficientFunds) {
+		writeError(w, http.StatusUnprocessableEntity, "INSUFFICIENT_FUNDS", err.Error())
+		return
+	}
+	if errors.Is(err, ErrInvalidPosting) || errors.Is(err, ErrEmptyPostings) {
+		writeError(w, http.StatusBadRequest, "INVALID_TRANSACTION", err.Error())
+		return
+	}
+	writeError(w, http.StatusBadRequest, "INVALID_TRANSACTION", err.Error())
+}
+
+func (s *Server) handleBudgets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "метод не поддерживается")
+		return
+	}
+	writeJSON(w, http.StatusOK, apiResponse{Success: true, Data: s.ledger.ListBudgets()})
+}
+
+func (s *Server) handleBudgetByCategory(w http.ResponseWriter, r *http.Request) {
+	category := strings.TrimPrefix(r.URL.Path, "/budgets/")
+	if category == "" {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "категория не указана")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		var body struct {
+			Limit  Money  `json:"limit"`
+			Period string `json:"period"`
+			Asset  string `json:"asset"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_BODY", err.Error())
+			return
+		}
+
+		budget := Budget{Category: category, Limit: body.Limit, Period: body.Period, Asset: body.Asset}
+		if err := s.ledger.SetBudget(budget); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_BUDGET", err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, apiResponse{Success: true, Data: budget})
+	case http.MethodGet:
+		budget, exists := s.ledger.GetBudget(category)
+		if !exists {
+			writeError(w, http.StatusNotFound, "BUDGET_NOT_FOUND", "бюджет для категории не найден")
+			return
+		}
+		writeJSON(w, http.StatusOK, apiResponse{Success: true, Data: budget})
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "метод не поддерживается")
+	}
+}
+
+func (s *Server) handleAccountBalance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "метод не поддерживается")
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/accounts/")
+	name, suffix, ok := strings.Cut(path, "/")
+	if !ok || suffix != "balance" || name == "" {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "маршрут не найден")
+		return
+	}
+
+	asset := r.URL.Query().Get("asset")
+	if asset == "" {
+		writeError(w, http.StatusBadRequest, "MISSING_ASSET", "необходимо указать query-параметр asset")
+		return
+	}
+
+	balance := s.ledger.GetAccountBalance(name, asset)
+	writeJSON(w, http.StatusOK, apiResponse{
+		Success: true,
+		Data: map[string]string{
+			"account": name,
+			"asset":   asset,
+			"balance": strconv.FormatInt(balance, 10),
+		},
+	})
+}
@@ -0,0 +1,560 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/MariianUm/Go-project/hw2/ledger/validation"
+)
+
+// WorldAccount — специальный счет-источник/приемник для внешних операций
+// (пополнения, списания за пределы системы). Для него баланс может уходить
+// в минус, в отличие от обычных счетов.
+const WorldAccount = "world"
+
+// Posting представляет одну проводку: перемещение положительной суммы
+// Amount (в минорных единицах актива Asset) со счета Source на счет Destination.
+type Posting struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Amount      int64  `json:"amount"`
+	Asset       string `json:"asset"`
+}
+
+// Transaction структура для представления транзакции
+type Transaction struct {
+	ID          int       `json:"id"`
+	Postings    []Posting `json:"postings"`
+	Description string    `json:"description"`
+	Date        time.Time `json:"date"`
+}
+
+// defaultAsset — актив, используемый для бюджетов и проводок, у которых
+// он не указан явно (сохраняет обратную совместимость с данными, созданными
+// до введения мультивалютности).
+const defaultAsset = "RUB"
+
+// Budget структура для хранения информации о бюджете. Limit хранится как
+// Money (минорные единицы), но на границе JSON выглядит как обычное число,
+// как и раньше.
+type Budget struct {
+	Category string `json:"category"`
+	Limit    Money  `json:"limit"`
+	Period   string `json:"period"`
+	Asset    string `json:"asset"`
+}
+
+var (
+	ErrEmptyPostings     = errors.New("транзакция должна содержать хотя бы одну проводку")
+	ErrInvalidPosting    = errors.New("проводка указана некорректно")
+	ErrInsufficientFunds = errors.New("недостаточно средств на счете")
+	// ErrBudgetExceeded — сентинел для errors.Is; BudgetExceededError
+	// оборачивает его через Unwrap, так что вызывающий код может либо
+	// сравнить через errors.Is(err, ErrBudgetExceeded), либо через
+	// errors.As(err, &budgetErr) получить структурированные поля.
+	ErrBudgetExceeded = errors.New("бюджет превышен")
+)
+
+// BudgetExceededError возвращается, когда проводка привела бы к превышению
+// лимита бюджета категории. В отличие от прочих ошибок пакета, несет
+// структурированные данные, чтобы вызывающий код (например, HTTP-хендлер)
+// мог вернуть их клиенту машиночитаемо.
+type BudgetExceededError struct {
+	Category  string
+	Current   int64
+	Attempted int64
+	Limit     int64
+}
+
+func (e *BudgetExceededError) Error() string {
+	return fmt.Sprintf("бюджет превышен для категории '%s': текущие %d + новые %d > лимит %d",
+		e.Category, e.Current, e.Attempted, e.Limit)
+}
+
+func (e *BudgetExceededError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+// Ledger владеет всем состоянием учетной книги (транзакции, бюджеты, балансы)
+// и отвечает за то, чтобы это состояние переживало перезапуск процесса.
+// Создавать Ledger напрямую не нужно — используйте NewLedger (только в
+// памяти) или Reopen (с журналом и снимками на диске).
+type Ledger struct {
+	mu sync.RWMutex
+
+	transactions    []Transaction
+	budgets         map[string]Budget
+	balances        map[string]map[string]int64
+	recurring       []RecurringRule
+	rates           RatesProvider
+	nextID          int
+	nextRecurringID int
+
+	walPath      string
+	snapshotPath string
+	wal          *walWriter
+}
+
+// DefaultLedger — процессный экземпляр учетной книги, на который опираются
+// пакетные функции-обертки из compat.go для обратной совместимости. В отличие от
+// книг, полученных через Reopen, он хранится только в памяти.
+var DefaultLedger = NewLedger()
+
+// NewLedger создает пустую учетную книгу без персистентности (без WAL и
+// снимков). Используется как DefaultLedger и в местах, где сохранение на
+// диск не требуется; для персистентной книги используйте Reopen.
+func NewLedger() *Ledger {
+	return &Ledger{
+		budgets:  make(map[string]Budget),
+		balances: make(map[string]map[string]int64),
+		rates:    NewCachingRatesProvider(NewStaticRatesProvider()),
+	}
+}
+
+// SetRatesProvider заменяет источник курсов обмена, используемый при
+// проверке бюджетов и конвертирующих отчетах (GetCategoryTotalIn,
+// ListTransactionsConverted). По умолчанию используется пустой
+// StaticRatesProvider. p оборачивается в CachingRatesProvider, чтобы
+// warmRates имело смысл подогревать курсы до захвата l.mu — без кэша
+// validateTransaction все равно повторял бы тот же (возможно, сетевой)
+// запрос уже под блокировкой.
+func (l *Ledger) SetRatesProvider(p RatesProvider) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rates = NewCachingRatesProvider(p)
+}
+
+// AddTransaction атомарно применяет все проводки транзакции: если хотя бы одна
+// проводка некорректна, превышает бюджет категории или уводит счет актива
+// в минус (кроме счета WorldAccount), вся транзакция отклоняется целиком.
+// При успехе возвращает присвоенный ID.
+func (l *Ledger) AddTransaction(tx Transaction) (int, error) {
+	if tx.Date.IsZero() {
+		tx.Date = time.Now()
+	}
+
+	l.warmRates(tx)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	projected, err := l.validateTransaction(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	tx.ID = l.nextID + 1
+
+	if l.wal != nil {
+		if err := l.wal.append(walRecord{Type: walRecordTx, Tx: &tx}); err != nil {
+			return 0, fmt.Errorf("не удалось записать транзакцию в журнал: %w", err)
+		}
+	}
+
+	l.applyTransaction(tx, projected)
+	return tx.ID, nil
+}
+
+// validateTransaction проверяет транзакцию и возвращает спроецированные
+// изменения балансов (счет -> актив -> дельта), не изменяя состояние Ledger.
+func (l *Ledger) validateTransaction(tx Transaction) (map[string]map[string]int64, error) {
+	if len(tx.Postings) == 0 {
+		return nil, ErrEmptyPostings
+	}
+	if err := validation.Description(tx.Description); err != nil {
+		return nil, err
+	}
+
+	projected := make(map[string]map[string]int64)
+	for _, p := range tx.Postings {
+		if p.Amount <= 0 {
+			return nil, fmt.Errorf("%w: сумма проводки должна быть положительной, получено %d", ErrInvalidPosting, p.Amount)
+		}
+		if p.Asset == "" {
+			return nil, fmt.Errorf("%w: не указан актив проводки", ErrInvalidPosting)
+		}
+		if p.Source == "" || p.Destination == "" {
+			return nil, fmt.Errorf("%w: должны быть указаны счет-источник и счет-получатель", ErrInvalidPosting)
+		}
+
+		if projected[p.Source] == nil {
+			projected[p.Source] = make(map[string]int64)
+		}
+		if projected[p.Destination] == nil {
+			projected[p.Destination] = make(map[string]int64)
+		}
+		projected[p.Source][p.Asset] -= p.Amount
+		projected[p.Destination][p.Asset] += p.Amount
+	}
+
+	// Проверка бюджета: считаем счет-получатель категорией расходов. Сумма
+	// проводки конвертируется в актив бюджета по курсу на дату транзакции.
+	// Проводки транзакции в одну и ту же категорию сначала суммируются между
+	// собой и лишь потом сверяются с currentTotal одним разом — иначе
+	// транзакция с двумя проводками по 6000 в категорию с лимитом 10000
+	// прошла бы целиком: каждая проводка по отдельности сверялась бы с одним
+	// и тем же currentTotal, не зная о соседней.
+	pendingByCategory := make(map[string]int64)
+	for _, p := range tx.Postings {
+		budget, exists := l.budgets[p.Destination]
+		if !exists {
+			continue
+		}
+		budgetAsset := budget.Asset
+		if budgetAsset == "" {
+			budgetAsset = defaultAsset
+		}
+
+		amount := p.Amount
+		if p.Asset != budgetAsset {
+			rate, err := l.rates.Rate(p.Asset, budgetAsset, tx.Date)
+			if err != nil {
+				return nil, err
+			}
+			amount = int64(math.Round(float64(p.Amount) * rate))
+		}
+		pendingByCategory[p.Destination] += amount
+	}
+
+	for category, pending := range pendingByCategory {
+		budget := l.budgets[category]
+		budgetAsset := budget.Asset
+		if budgetAsset == "" {
+			budgetAsset = defaultAsset
+		}
+
+		// currentTotal намеренно лучшее-из-возможного: проводка в другой актив
+		// из далекого прошлого, для которой курс на тот день не сохранился,
+		// не должна блокировать новые операции по категории — особенно те,
+		// что вообще не требуют конвертации. Ошибку здесь не возвращаем;
+		// строгая версия с пропагацией ошибки — GetCategoryTotalIn.
+		currentTotal, _ := l.categoryTotalInLocked(category, budgetAsset, tx.Date)
+
+		if currentTotal+pending > budget.Limit.Minor {
+			return nil, &BudgetExceededError{
+				Category:  category,
+				Current:   currentTotal,
+				Attempted: pending,
+				Limit:     budget.Limit.Minor,
+			}
+		}
+	}
+
+	// Проверка, что итоговый баланс счетов активов (кроме world) не уходит в минус.
+	for account, deltas := range projected {
+		if account == WorldAccount {
+			continue
+		}
+		for asset, delta := range deltas {
+			if l.balanceLocked(account, asset)+delta < 0 {
+				return nil, fmt.Errorf("%w: счет %s, актив %s", ErrInsufficientFunds, account, asset)
+			}
+		}
+	}
+
+	return projected, nil
+}
+
+// rateKey идентифицирует запрошенный курс обмена на конкретную дату.
+type rateKey struct {
+	from, to string
+	date     time.Time
+}
+
+// warmRates заранее получает курсы обмена, которые понадобятся проверке
+// бюджета для tx, не удерживая l.mu. RatesProvider (например,
+// HTTPRatesProvider) может выполнять синхронный сетевой запрос — если бы
+// validateTransaction вызывал его под l.mu.Lock(), одно медленное обращение
+// к сервису курсов останавливало бы всех читателей и писателей книги, а не
+// только операции с затронутой категорией. Здесь курсы лишь подгреваются в
+// кэш провайдера; сама проверка ниже все равно обращается к RatesProvider
+// штатно и корректно работает, даже если что-то не попало в кэш.
+func (l *Ledger) warmRates(tx Transaction) {
+	needed := make(map[rateKey]struct{})
+
+	l.mu.RLock()
+	for _, p := range tx.Postings {
+		budget, exists := l.budgets[p.Destination]
+		if !exists {
+			continue
+		}
+		budgetAsset := budget.Asset
+		if budgetAsset == "" {
+			budgetAsset = defaultAsset
+		}
+		if p.Asset != budgetAsset {
+			needed[rateKey{p.Asset, budgetAsset, tx.Date}] = struct{}{}
+		}
+		for _, histTx := range l.transactions {
+			for _, histP := range histTx.Postings {
+				if histP.Destination == p.Destination && histP.Asset != budgetAsset {
+					needed[rateKey{histP.Asset, budgetAsset, histTx.Date}] = struct{}{}
+				}
+			}
+		}
+	}
+	l.mu.RUnlock()
+
+	for key := range needed {
+		_, _ = l.rates.Rate(key.from, key.to, key.date)
+	}
+}
+
+// applyTransaction применяет уже провалидированную транзакцию к состоянию
+// в памяти. Вызывающая сторона отвечает за предварительную запись в WAL.
+func (l *Ledger) applyTransaction(tx Transaction, projected map[string]map[string]int64) {
+	for account, deltas := range projected {
+		if l.balances[account] == nil {
+			l.balances[account] = make(map[string]int64)
+		}
+		for asset, delta := range deltas {
+			l.balances[account][asset] += delta
+		}
+	}
+
+	l.transactions = append(l.transactions, tx)
+	if tx.ID > l.nextID {
+		l.nextID = tx.ID
+	}
+}
+
+// calculateCategoryTotal вычисляет сумму проводок, зачисленных на счет
+// категории category, в ее основном активе (актив бюджета категории, либо
+// defaultAsset, если бюджет не задан или актив в нем пуст). Используется
+// там, где отсутствие части курсов не должно приводить к ошибке (легаси-
+// отчетность); для строгой конвертации используйте GetCategoryTotalIn.
+func (l *Ledger) calculateCategoryTotal(category string) int64 {
+	asset := defaultAsset
+	if budget, ok := l.budgets[category]; ok && budget.Asset != "" {
+		asset = budget.Asset
+	}
+	// Проводки, для которых курс не нашелся, categoryTotalInLocked уже
+	// пропускает сама — total здесь покрывает все остальные, независимо от
+	// их положения в списке транзакций.
+	total, _ := l.categoryTotalInLocked(category, asset, time.Now())
+	return total
+}
+
+// categoryTotalInLocked суммирует проводки, зачисленные на счет категории
+// category, конвертируя каждую в asset по курсу на дату соответствующей
+// транзакции. Если для категории задан бюджет, учитываются только
+// транзакции в текущем окне периода этого бюджета. Вызывающая сторона
+// обязана уже держать l.mu. Проводка, для которой курс конвертации не
+// нашелся, пропускается (не учитывается в total) вместо прерывания всего
+// подсчета — иначе одна старая нерелевантная проводка блокировала бы учет
+// всех последующих. Если хотя бы одна проводка была пропущена, возвращается
+// первая такая ошибка вместе с total, посчитанным по всем остальным.
+func (l *Ledger) categoryTotalInLocked(category, asset string, asOf time.Time) (int64, error) {
+	windowStart, windowEnd := time.Time{}, time.Time{}
+	if budget, ok := l.budgets[category]; ok {
+		windowStart, windowEnd = periodWindow(budget.Period, asOf)
+	}
+
+	var total int64
+	var firstErr error
+	for _, tx := range l.transactions {
+		if !windowStart.IsZero() && (tx.Date.Before(windowStart) || !tx.Date.Before(windowEnd)) {
+			continue
+		}
+		for _, p := range tx.Postings {
+			if p.Destination != category {
+				continue
+			}
+			amount := p.Amount
+			if p.Asset != asset {
+				rate, err := l.rates.Rate(p.Asset, asset, tx.Date)
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					continue
+				}
+				amount = int64(math.Round(float64(p.Amount) * rate))
+			}
+			total += amount
+		}
+	}
+	return total, firstErr
+}
+
+// GetCategoryTotalIn возвращает сумму проводок категории category,
+// конвертированную в asset по историческим курсам на даты транзакций.
+// Возвращает ErrRateUnavailable, если для какой-то проводки курса нет.
+func (l *Ledger) GetCategoryTotalIn(category, asset string) (int64, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.categoryTotalInLocked(category, asset, time.Now())
+}
+
+// ConvertedTransaction — транзакция вместе с суммой ее проводок,
+// конвертированной в единый актив для отчетности.
+type ConvertedTransaction struct {
+	Transaction
+	ConvertedTotal int64
+}
+
+// ListTransactionsConverted возвращает все транзакции с суммой их
+// проводок, конвертированной в asset по курсу на дату каждой транзакции.
+// Возвращает ErrRateUnavailable, если для какой-то проводки курса нет.
+func (l *Ledger) ListTransactionsConverted(asset string) ([]ConvertedTransaction, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := make([]ConvertedTransaction, 0, len(l.transactions))
+	for _, tx := range l.transactions {
+		var total int64
+		for _, p := range tx.Postings {
+			amount := p.Amount
+			if p.Asset != asset {
+				rate, err := l.rates.Rate(p.Asset, asset, tx.Date)
+				if err != nil {
+					return nil, fmt.Errorf("транзакция %d: %w", tx.ID, err)
+				}
+				amount = int64(math.Round(float64(p.Amount) * rate))
+			}
+			total += amount
+		}
+		result = append(result, ConvertedTransaction{Transaction: tx, ConvertedTotal: total})
+	}
+	return result, nil
+}
+
+// periodWindow возвращает границы [start, end) текущего окна периода
+// period относительно момента now. Для нераспознанного периода (например,
+// cron-выражения) возвращает нулевые значения — окно не ограничивается.
+func periodWindow(period string, now time.Time) (time.Time, time.Time) {
+	switch period {
+	case "daily":
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 0, 1)
+	case "weekly":
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+		start = start.AddDate(0, 0, -int(start.Weekday()))
+		return start, start.AddDate(0, 0, 7)
+	case "monthly":
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0)
+	default:
+		return time.Time{}, time.Time{}
+	}
+}
+
+// balanceLocked возвращает баланс счета без захвата мьютекса; вызывающая
+// сторона обязана уже держать l.mu (на чтение или на запись).
+func (l *Ledger) balanceLocked(account, asset string) int64 {
+	return l.balances[account][asset]
+}
+
+// GetAccountBalance возвращает текущий баланс счета account в активе asset.
+func (l *Ledger) GetAccountBalance(account, asset string) int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.balanceLocked(account, asset)
+}
+
+// ListPostings возвращает все проводки, в которых участвует счет account,
+// в порядке добавления транзакций.
+func (l *Ledger) ListPostings(account string) []Posting {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var result []Posting
+	for _, tx := range l.transactions {
+		for _, p := range tx.Postings {
+			if p.Source == account || p.Destination == account {
+				result = append(result, p)
+			}
+		}
+	}
+	return result
+}
+
+// ListTransactions возвращает все транзакции
+func (l *Ledger) ListTransactions() []Transaction {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := make([]Transaction, len(l.transactions))
+	copy(result, l.transactions)
+	return result
+}
+
+// SetBudget добавляет или обновляет бюджет в хранилище
+func (l *Ledger) SetBudget(b Budget) error {
+	if err := validation.Category(b.Category); err != nil {
+		return err
+	}
+	if b.Limit.Minor <= 0 {
+		return fmt.Errorf("%w: лимит бюджета должен быть положительным", validation.ErrInvalidAmount)
+	}
+	if b.Asset == "" {
+		b.Asset = defaultAsset
+	}
+	b.Limit.Asset = b.Asset
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.wal != nil {
+		if err := l.wal.append(walRecord{Type: walRecordBudget, Budget: &b}); err != nil {
+			return fmt.Errorf("не удалось записать бюджет в журнал: %w", err)
+		}
+	}
+
+	l.budgets[b.Category] = b
+	return nil
+}
+
+// GetBudget возвращает бюджет для категории
+func (l *Ledger) GetBudget(category string) (Budget, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	budget, exists := l.budgets[category]
+	return budget, exists
+}
+
+// ListBudgets возвращает все бюджеты
+func (l *Ledger) ListBudgets() map[string]Budget {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := make(map[string]Budget)
+	for k, v := range l.budgets {
+		result[k] = v
+	}
+	return result
+}
+
+// GetCategoryTotal возвращает текущую сумму по категории в основных единицах
+// ее основного актива (актив бюджета категории, по умолчанию RUB)
+func (l *Ledger) GetCategoryTotal(category string) float64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return float64(l.calculateCategoryTotal(category)) / 100
+}
+
+// LoadBudgets загружает бюджеты из JSON
+func (l *Ledger) LoadBudgets(r io.Reader) error {
+	var budgetList []Budget
+
+	reader := bufio.NewReader(r)
+	decoder := json.NewDecoder(reader)
+
+	if err := decoder.Decode(&budgetList); err != nil {
+		return fmt.Errorf("ошибка парсинга JSON: %v", err)
+	}
+
+	for _, budget := range budgetList {
+		if err := l.SetBudget(budget); err != nil {
+			return fmt.Errorf("ошибка установки бюджета для %s: %v", budget.Category, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestConcurrentAddTransactionBudgetInvariant fires 1000 concurrent
+// AddTransaction calls split across two overlapping categories and checks
+// that the budget invariant holds exactly once every goroutine finishes: the
+// accepted count per category must match exactly how many transactions the
+// budget allows, and the recorded total must equal accepted*amount exactly.
+// A race in the budget check (e.g. reading currentTotal outside l.mu) would
+// let more transactions through than the limit permits — run with -race to
+// also catch data races in the shared Ledger state.
+func TestConcurrentAddTransactionBudgetInvariant(t *testing.T) {
+	l := NewLedger()
+
+	const (
+		attemptsPerCategory = 500
+		amountMinor         = 100   // 1.00 RUB per transaction
+		acceptedPerCategory = 300   // budget allows exactly this many to land
+		limitMinor          = acceptedPerCategory * amountMinor
+	)
+
+	categories := []string{"Еда", "Транспорт"}
+	for _, category := range categories {
+		if err := l.SetBudget(Budget{
+			Category: category,
+			Limit:    Money{Minor: limitMinor, Asset: defaultAsset},
+			Period:   "monthly",
+		}); err != nil {
+			t.Fatalf("не удалось установить бюджет %s: %v", category, err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	var accepted [2]int64
+
+	for i := 0; i < attemptsPerCategory*len(categories); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			catIdx := i % len(categories)
+			_, err := l.AddTransaction(Transaction{
+				Postings: []Posting{
+					{Source: WorldAccount, Destination: categories[catIdx], Amount: amountMinor, Asset: defaultAsset},
+				},
+				Description: fmt.Sprintf("конкурентная транзакция %d", i),
+				Date:        time.Now(),
+			})
+			if err == nil {
+				atomic.AddInt64(&accepted[catIdx], 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for idx, category := range categories {
+		got := atomic.LoadInt64(&accepted[idx])
+		if got != acceptedPerCategory {
+			t.Fatalf("категория %s: принято %d транзакций, ожидалось ровно %d", category, got, acceptedPerCategory)
+		}
+
+		total, err := l.GetCategoryTotalIn(category, defaultAsset)
+		if err != nil {
+			t.Fatalf("категория %s: не удалось получить сумму: %v", category, err)
+		}
+		if total != limitMinor {
+			t.Fatalf("категория %s: сумма %d не равна лимиту %d", category, total, limitMinor)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestAddTransactionRejectsSameCategoryOverdraft защищает от регрессии
+// бага, из-за которого несколько проводок одной транзакции в одну и ту же
+// категорию сверялись с одним и тем же currentTotal по отдельности и вместе
+// проходили сквозь лимит: лимит 10000, две проводки по 6000 в одну
+// категорию должны быть отклонены целиком, а не приняты с итогом 12000.
+func TestAddTransactionRejectsSameCategoryOverdraft(t *testing.T) {
+	l := NewLedger()
+	if err := l.SetBudget(Budget{Category: "Еда", Limit: Money{Minor: 10000, Asset: defaultAsset}, Period: "monthly"}); err != nil {
+		t.Fatalf("не удалось установить бюджет: %v", err)
+	}
+
+	_, err := l.AddTransaction(Transaction{Postings: []Posting{
+		{Source: WorldAccount, Destination: "Еда", Amount: 6000, Asset: defaultAsset},
+		{Source: WorldAccount, Destination: "Еда", Amount: 6000, Asset: defaultAsset},
+	}})
+
+	var budgetErr *BudgetExceededError
+	if !errors.As(err, &budgetErr) {
+		t.Fatalf("ожидали *BudgetExceededError, получили %v", err)
+	}
+	if budgetErr.Attempted != 12000 {
+		t.Fatalf("ожидали Attempted=12000 (сумма обеих проводок), получили %d", budgetErr.Attempted)
+	}
+
+	total := l.calculateCategoryTotal("Еда")
+	if total != 0 {
+		t.Fatalf("транзакция должна быть отклонена целиком, но категория все равно получила %d", total)
+	}
+}
+
+// TestAddTransactionRejectsInsufficientFunds проверяет, что транзакция,
+// уводящая обычный счет в минус, отклоняется целиком и не меняет балансы.
+func TestAddTransactionRejectsInsufficientFunds(t *testing.T) {
+	l := NewLedger()
+
+	if _, err := l.AddTransaction(Transaction{Postings: []Posting{
+		{Source: WorldAccount, Destination: "alice", Amount: 1000, Asset: defaultAsset},
+	}}); err != nil {
+		t.Fatalf("не удалось пополнить счет: %v", err)
+	}
+
+	_, err := l.AddTransaction(Transaction{Postings: []Posting{
+		{Source: "alice", Destination: "bob", Amount: 2000, Asset: defaultAsset},
+	}})
+	if !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("ожидали ErrInsufficientFunds, получили %v", err)
+	}
+
+	if balance := l.GetAccountBalance("alice", defaultAsset); balance != 1000 {
+		t.Fatalf("баланс alice не должен был измениться отклоненной транзакцией, получили %d", balance)
+	}
+}
+
+// TestAddTransactionRejectsEmptyPostings проверяет, что транзакция без
+// проводок отклоняется с ErrEmptyPostings, а не тихо принимается как пустая.
+func TestAddTransactionRejectsEmptyPostings(t *testing.T) {
+	l := NewLedger()
+	if _, err := l.AddTransaction(Transaction{}); !errors.Is(err, ErrEmptyPostings) {
+		t.Fatalf("ожидали ErrEmptyPostings, получили %v", err)
+	}
+}
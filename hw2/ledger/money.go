@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MariianUm/Go-project/hw2/ledger/validation"
+)
+
+// ErrAssetMismatch возвращается при попытке сложить, вычесть или сравнить
+// суммы в разных активах.
+var ErrAssetMismatch = errors.New("операция между суммами разных активов")
+
+// Money — сумма в фиксированном формате: минорные единицы актива (копейки,
+// центы и т.п.) плюс сам актив. Заменяет float64 во внутренних вычислениях,
+// чтобы исключить дрейф округления (например, 1000.00 + 2000.00 > 6000.00
+// из-за погрешности float64). На границе JSON сериализуется как обычное
+// десятичное число — так же, как раньше float64 — чтобы не менять формат
+// budgets.json и HTTP API; разбор при этом проходит через валидацию.
+type Money struct {
+	Minor int64
+	Asset string
+}
+
+// NewMoneyFromMajor проверяет и переводит сумму major (например, рубли с
+// копейками) в Money актива asset.
+func NewMoneyFromMajor(major float64, asset string) (Money, error) {
+	minor, err := validation.AmountMinorUnits(major)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Minor: minor, Asset: asset}, nil
+}
+
+// Major возвращает сумму в основных единицах (рубли, доллары и т.д.).
+func (m Money) Major() float64 {
+	return float64(m.Minor) / 100
+}
+
+// Add складывает суммы одного актива.
+func (m Money) Add(other Money) (Money, error) {
+	if m.Asset != other.Asset {
+		return Money{}, fmt.Errorf("%w: %s и %s", ErrAssetMismatch, m.Asset, other.Asset)
+	}
+	return Money{Minor: m.Minor + other.Minor, Asset: m.Asset}, nil
+}
+
+// Sub вычитает суммы одного актива.
+func (m Money) Sub(other Money) (Money, error) {
+	if m.Asset != other.Asset {
+		return Money{}, fmt.Errorf("%w: %s и %s", ErrAssetMismatch, m.Asset, other.Asset)
+	}
+	return Money{Minor: m.Minor - other.Minor, Asset: m.Asset}, nil
+}
+
+// Cmp сравнивает суммы одного актива: -1, если m меньше other, 0 если равны,
+// 1 если m больше other.
+func (m Money) Cmp(other Money) (int, error) {
+	if m.Asset != other.Asset {
+		return 0, fmt.Errorf("%w: %s и %s", ErrAssetMismatch, m.Asset, other.Asset)
+	}
+	switch {
+	case m.Minor < other.Minor:
+		return -1, nil
+	case m.Minor > other.Minor:
+		return 1, nil
+	default:
+		return 0, nil
+	}
+}
+
+func (m Money) String() string {
+	return fmt.Sprintf("%.2f %s", m.Major(), m.Asset)
+}
+
+// MarshalJSON сериализует Money как простое десятичное число (как раньше
+// float64), не включая актив — актив в бюджете и так хранится отдельным
+// полем JSON.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Major())
+}
+
+// UnmarshalJSON разбирает Money из простого десятичного числа, проверяя
+// точность до двух знаков после запятой. Актив при этом не заполняется —
+// это обязанность вызывающей стороны (например, Budget.Asset).
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var major float64
+	if err := json.Unmarshal(data, &major); err != nil {
+		return err
+	}
+	minor, err := validation.AmountMinorUnits(major)
+	if err != nil {
+		return err
+	}
+	m.Minor = minor
+	return nil
+}
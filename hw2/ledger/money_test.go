@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestMoneyAddSubCmpPrecision проверяет, что Add/Sub/Cmp работают над
+// целыми минорными единицами (см. money.go): 1000.00 + 2000.00 дает ровно
+// 300000 минорных единиц, вычитание возвращает исходную сумму без
+// погрешности, а Cmp упорядочивает суммы одного актива.
+func TestMoneyAddSubCmpPrecision(t *testing.T) {
+	a, err := NewMoneyFromMajor(1000, defaultAsset)
+	if err != nil {
+		t.Fatalf("не удалось создать a: %v", err)
+	}
+	b, err := NewMoneyFromMajor(2000, defaultAsset)
+	if err != nil {
+		t.Fatalf("не удалось создать b: %v", err)
+	}
+
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatalf("Add вернул ошибку: %v", err)
+	}
+	if sum.Minor != 300000 {
+		t.Fatalf("ожидали 300000 минорных единиц, получили %d", sum.Minor)
+	}
+
+	diff, err := sum.Sub(a)
+	if err != nil {
+		t.Fatalf("Sub вернул ошибку: %v", err)
+	}
+	if diff.Minor != b.Minor {
+		t.Fatalf("ожидали %d минорных единиц, получили %d", b.Minor, diff.Minor)
+	}
+
+	if cmp, err := a.Cmp(b); err != nil || cmp != -1 {
+		t.Fatalf("a.Cmp(b) = %d, %v; ожидали -1, nil", cmp, err)
+	}
+	if cmp, err := b.Cmp(a); err != nil || cmp != 1 {
+		t.Fatalf("b.Cmp(a) = %d, %v; ожидали 1, nil", cmp, err)
+	}
+	if cmp, err := a.Cmp(a); err != nil || cmp != 0 {
+		t.Fatalf("a.Cmp(a) = %d, %v; ожидали 0, nil", cmp, err)
+	}
+}
+
+// TestMoneyAssetMismatch проверяет, что Add/Sub/Cmp между разными активами
+// отклоняются с ErrAssetMismatch, а не молча считают их взаимозаменяемыми.
+func TestMoneyAssetMismatch(t *testing.T) {
+	rub, err := NewMoneyFromMajor(100, "RUB")
+	if err != nil {
+		t.Fatalf("не удалось создать rub: %v", err)
+	}
+	usd, err := NewMoneyFromMajor(100, "USD")
+	if err != nil {
+		t.Fatalf("не удалось создать usd: %v", err)
+	}
+
+	if _, err := rub.Add(usd); !errors.Is(err, ErrAssetMismatch) {
+		t.Fatalf("Add между разными активами: ожидали ErrAssetMismatch, получили %v", err)
+	}
+	if _, err := rub.Sub(usd); !errors.Is(err, ErrAssetMismatch) {
+		t.Fatalf("Sub между разными активами: ожидали ErrAssetMismatch, получили %v", err)
+	}
+	if _, err := rub.Cmp(usd); !errors.Is(err, ErrAssetMismatch) {
+		t.Fatalf("Cmp между разными активами: ожидали ErrAssetMismatch, получили %v", err)
+	}
+}
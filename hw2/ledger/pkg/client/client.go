@@ -0,0 +1,195 @@
+// Package client содержит типизированный HTTP-клиент для Ledger API,
+// описанного в hw2/ledger/openapi.yaml. Типы здесь намеренно независимы
+// от internal-типов сервера (пакета main) — так же, как это делает
+// сгенерированный oapi-codegen клиент.
+//
+// Это все еще не сгенерированный код: oapi-codegen требует сетевого модуля
+// (github.com/oapi-codegen/oapi-codegen/v2), который этот репозиторий пока не
+// вендорит и не объявляет как build-зависимость, поэтому go:generate ниже
+// сегодня не запускается в CI. Он зафиксирован как конкретная команда на
+// будущее, когда зависимость инструмента будет добавлена в go.mod; до тех пор
+// этот клиент и http.go ведутся вручную, и любое изменение формата
+// ответа/запроса сервера должно в том же коммите обновить оба: схему в
+// openapi.yaml и соответствующий тип здесь.
+//
+//go:generate go run github.com/oapi-codegen/oapi-codegen/v2/cmd/oapi-codegen -generate types,client -package client -o client.gen.go ../../openapi.yaml
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Posting — одна проводка транзакции.
+type Posting struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Amount      int64  `json:"amount"`
+	Asset       string `json:"asset"`
+}
+
+// Transaction — транзакция, как она передается через API.
+type Transaction struct {
+	ID          int       `json:"id,omitempty"`
+	Postings    []Posting `json:"postings"`
+	Description string    `json:"description,omitempty"`
+}
+
+// Budget — бюджет категории.
+type Budget struct {
+	Category string  `json:"category"`
+	Limit    float64 `json:"limit"`
+	Period   string  `json:"period"`
+	Asset    string  `json:"asset,omitempty"`
+}
+
+// APIError описывает структурированную ошибку, которую вернул сервер.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("ledger API: %s (код %s, статус %d)", e.Message, e.Code, e.StatusCode)
+}
+
+// Client — типизированный клиент Ledger API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient создает клиента, обращающегося к серверу по адресу baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{baseURL: baseURL, httpClient: http.DefaultClient}
+}
+
+type envelope struct {
+	Success bool            `json:"success"`
+	Code    string          `json:"code,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (c *Client) do(req *http.Request, out interface{}) error {
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	if err := json.NewDecoder(resp.Body).Decode(&env); err != nil {
+		return fmt.Errorf("не удалось разобрать ответ сервера: %w", err)
+	}
+
+	if !env.Success {
+		return &APIError{StatusCode: resp.StatusCode, Code: env.Code, Message: env.Error}
+	}
+	if out != nil && env.Data != nil {
+		return json.Unmarshal(env.Data, out)
+	}
+	return nil
+}
+
+// CreateTransaction отправляет транзакцию на сервер и возвращает присвоенный ID.
+func (c *Client) CreateTransaction(tx Transaction) (int, error) {
+	body, err := json.Marshal(tx)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/transactions", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		ID int `json:"id"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return 0, err
+	}
+	return result.ID, nil
+}
+
+// ListTransactions возвращает все транзакции.
+func (c *Client) ListTransactions() ([]Transaction, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/transactions", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []Transaction
+	if err := c.do(req, &txs); err != nil {
+		return nil, err
+	}
+	return txs, nil
+}
+
+// SetBudget устанавливает или обновляет бюджет категории. asset может быть
+// пустым — тогда сервер применяет актив по умолчанию (RUB).
+func (c *Client) SetBudget(category string, limit float64, period, asset string) (Budget, error) {
+	body, err := json.Marshal(struct {
+		Limit  float64 `json:"limit"`
+		Period string  `json:"period"`
+		Asset  string  `json:"asset,omitempty"`
+	}{Limit: limit, Period: period, Asset: asset})
+	if err != nil {
+		return Budget{}, err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, c.baseURL+"/budgets/"+url.PathEscape(category), bytes.NewReader(body))
+	if err != nil {
+		return Budget{}, err
+	}
+
+	var budget Budget
+	if err := c.do(req, &budget); err != nil {
+		return Budget{}, err
+	}
+	return budget, nil
+}
+
+// ListBudgets возвращает все бюджеты по категориям.
+func (c *Client) ListBudgets() (map[string]Budget, error) {
+	req, err := http.NewRequest(http.MethodGet, c.baseURL+"/budgets", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var budgets map[string]Budget
+	if err := c.do(req, &budgets); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// GetAccountBalance возвращает баланс счета account в активе asset.
+func (c *Client) GetAccountBalance(account, asset string) (int64, error) {
+	endpoint := fmt.Sprintf("%s/accounts/%s/balance?asset=%s",
+		c.baseURL, url.PathEscape(account), url.QueryEscape(asset))
+
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		Balance string `json:"balance"`
+	}
+	if err := c.do(req, &result); err != nil {
+		return 0, err
+	}
+
+	var balance int64
+	if _, err := fmt.Sscanf(result.Balance, "%d", &balance); err != nil {
+		return 0, fmt.Errorf("не удалось разобрать баланс %q: %w", result.Balance, err)
+	}
+	return balance, nil
+}
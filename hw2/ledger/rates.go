@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrRateUnavailable возвращается, когда для пары активов на нужную дату
+// нет курса. Конвертация никогда не подставляет 1.0 вместо отсутствующего
+// курса — это маскирует реальные расхождения в отчетности и проверках бюджета.
+var ErrRateUnavailable = errors.New("курс обмена недоступен")
+
+// RatesProvider возвращает курс обмена: сколько единиц актива to дают за
+// одну единицу актива from на дату on (курсы берутся с точностью до дня).
+type RatesProvider interface {
+	Rate(from, to string, on time.Time) (float64, error)
+}
+
+func ratesCacheKey(from, to string, on time.Time) string {
+	return on.Format("2006-01-02") + ":" + from + "/" + to
+}
+
+// StaticRatesProvider хранит курсы в памяти, проставленные заранее через
+// SetRate. Подходит для тестов и небольших детерминированных сценариев.
+type StaticRatesProvider struct {
+	mu    sync.RWMutex
+	rates map[string]float64
+}
+
+// NewStaticRatesProvider создает пустой провайдер без курсов.
+func NewStaticRatesProvider() *StaticRatesProvider {
+	return &StaticRatesProvider{rates: make(map[string]float64)}
+}
+
+// SetRate задает курс from->to, действующий весь день on.
+func (p *StaticRatesProvider) SetRate(from, to string, on time.Time, rate float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rates[ratesCacheKey(from, to, on)] = rate
+}
+
+// Rate реализует RatesProvider.
+func (p *StaticRatesProvider) Rate(from, to string, on time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if rate, ok := p.rates[ratesCacheKey(from, to, on)]; ok {
+		return rate, nil
+	}
+	if inverse, ok := p.rates[ratesCacheKey(to, from, on)]; ok && inverse != 0 {
+		return 1 / inverse, nil
+	}
+	return 0, fmt.Errorf("%w: %s -> %s на %s", ErrRateUnavailable, from, to, on.Format("2006-01-02"))
+}
+
+// CachingRatesProvider оборачивает другой RatesProvider и запоминает уже
+// полученные курсы по (from, to, date), чтобы не повторять дорогие операции
+// (например, HTTP-запросы HTTPRatesProvider) при каждом обращении к одной и
+// той же паре на одну дату. Исторический курс на конкретный день считается
+// неизменным, поэтому кэш не инвалидируется. Ledger оборачивает в него любой
+// переданный ему провайдер — это делает осмысленным "подогрев" курсов в
+// warmRates до захвата l.mu: без кэша повторный вызов под блокировкой снова
+// выполнял бы тот же сетевой запрос.
+type CachingRatesProvider struct {
+	underlying RatesProvider
+
+	mu    sync.RWMutex
+	cache map[string]float64
+}
+
+// NewCachingRatesProvider оборачивает underlying кэширующим слоем.
+func NewCachingRatesProvider(underlying RatesProvider) *CachingRatesProvider {
+	return &CachingRatesProvider{underlying: underlying, cache: make(map[string]float64)}
+}
+
+// Rate реализует RatesProvider.
+func (p *CachingRatesProvider) Rate(from, to string, on time.Time) (float64, error) {
+	key := ratesCacheKey(from, to, on)
+
+	p.mu.RLock()
+	if rate, ok := p.cache[key]; ok {
+		p.mu.RUnlock()
+		return rate, nil
+	}
+	p.mu.RUnlock()
+
+	rate, err := p.underlying.Rate(from, to, on)
+	if err != nil {
+		return 0, err
+	}
+
+	p.mu.Lock()
+	p.cache[key] = rate
+	p.mu.Unlock()
+	return rate, nil
+}
+
+// HTTPRatesProvider запрашивает исторические курсы у внешнего сервиса.
+// Ожидается, что сервис отвечает JSON-объектом {"rate": <float>} на
+// GET {baseURL}/rates?from=X&to=Y&date=YYYY-MM-DD.
+type HTTPRatesProvider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewHTTPRatesProvider создает провайдер, ходящий за курсами по baseURL.
+func NewHTTPRatesProvider(baseURL string) *HTTPRatesProvider {
+	return &HTTPRatesProvider{baseURL: baseURL, httpClient: http.DefaultClient}
+}
+
+func (p *HTTPRatesProvider) Rate(from, to string, on time.Time) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	endpoint := fmt.Sprintf("%s/rates?from=%s&to=%s&date=%s",
+		p.baseURL, url.QueryEscape(from), url.QueryEscape(to), on.Format("2006-01-02"))
+
+	resp, err := p.httpClient.Get(endpoint)
+	if err != nil {
+		return 0, fmt.Errorf("не удалось запросить курс: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, fmt.Errorf("%w: %s -> %s на %s", ErrRateUnavailable, from, to, on.Format("2006-01-02"))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("сервис курсов ответил статусом %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Rate float64 `json:"rate"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("не удалось разобрать ответ сервиса курсов: %w", err)
+	}
+	if body.Rate == 0 {
+		return 0, fmt.Errorf("%w: %s -> %s на %s", ErrRateUnavailable, from, to, on.Format("2006-01-02"))
+	}
+	return body.Rate, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestStaticRatesProviderMissingRate проверяет, что запрос курса для пары,
+// для которой SetRate не вызывался, возвращает ErrRateUnavailable вместо
+// подстановки 1.0 — конвертация не должна маскировать отсутствие курса.
+func TestStaticRatesProviderMissingRate(t *testing.T) {
+	p := NewStaticRatesProvider()
+
+	if _, err := p.Rate("USD", "RUB", time.Now()); !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("ожидали ErrRateUnavailable, получили %v", err)
+	}
+}
+
+// TestStaticRatesProviderInverseRate проверяет, что при отсутствии прямого
+// курса from->to используется обратный курс to->from, если он задан.
+func TestStaticRatesProviderInverseRate(t *testing.T) {
+	p := NewStaticRatesProvider()
+	on := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.SetRate("RUB", "USD", on, 0.01)
+
+	rate, err := p.Rate("USD", "RUB", on)
+	if err != nil {
+		t.Fatalf("Rate вернул ошибку: %v", err)
+	}
+	if rate != 100 {
+		t.Fatalf("ожидали обратный курс 100, получили %v", rate)
+	}
+}
+
+// TestStaticRatesProviderSameAsset проверяет, что курс одного актива к
+// самому себе всегда 1, даже если он не был явно задан.
+func TestStaticRatesProviderSameAsset(t *testing.T) {
+	p := NewStaticRatesProvider()
+	rate, err := p.Rate("RUB", "RUB", time.Now())
+	if err != nil {
+		t.Fatalf("Rate вернул ошибку: %v", err)
+	}
+	if rate != 1 {
+		t.Fatalf("ожидали курс 1, получили %v", rate)
+	}
+}
+
+// failingOnceProvider возвращает ErrRateUnavailable первый раз и успешный
+// курс при каждом последующем вызове — используется, чтобы отличить кэш
+// CachingRatesProvider от реального обращения к нижележащему провайдеру.
+type failingOnceProvider struct {
+	calls int
+}
+
+func (p *failingOnceProvider) Rate(from, to string, on time.Time) (float64, error) {
+	p.calls++
+	if p.calls == 1 {
+		return 0, ErrRateUnavailable
+	}
+	return 2, nil
+}
+
+// TestCachingRatesProviderDoesNotCacheErrors проверяет, что
+// CachingRatesProvider не запоминает неудачные обращения — отсутствующий
+// сегодня курс может появиться после следующего успешного запроса.
+func TestCachingRatesProviderDoesNotCacheErrors(t *testing.T) {
+	underlying := &failingOnceProvider{}
+	p := NewCachingRatesProvider(underlying)
+	on := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := p.Rate("USD", "RUB", on); !errors.Is(err, ErrRateUnavailable) {
+		t.Fatalf("ожидали ErrRateUnavailable при первом обращении, получили %v", err)
+	}
+
+	rate, err := p.Rate("USD", "RUB", on)
+	if err != nil {
+		t.Fatalf("второе обращение не должно было повторить ошибку: %v", err)
+	}
+	if rate != 2 {
+		t.Fatalf("ожидали курс 2, получили %v", rate)
+	}
+	if underlying.calls != 2 {
+		t.Fatalf("ожидали 2 обращения к нижележащему провайдеру, получили %d", underlying.calls)
+	}
+}
+
+// TestCachingRatesProviderCachesSuccess проверяет, что повторный запрос той
+// же пары на ту же дату отдается из кэша и не доходит до нижележащего
+// провайдера — ради чего warmRates и подогревает курсы до захвата l.mu.
+func TestCachingRatesProviderCachesSuccess(t *testing.T) {
+	underlying := &failingOnceProvider{calls: 1} // первый вызов сразу успешен
+	p := NewCachingRatesProvider(underlying)
+	on := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	if _, err := p.Rate("USD", "RUB", on); err != nil {
+		t.Fatalf("Rate вернул ошибку: %v", err)
+	}
+	if _, err := p.Rate("USD", "RUB", on); err != nil {
+		t.Fatalf("Rate вернул ошибку: %v", err)
+	}
+	if underlying.calls != 2 {
+		t.Fatalf("второй запрос должен был попасть в кэш, но нижележащий провайдер вызван %d раз", underlying.calls)
+	}
+}
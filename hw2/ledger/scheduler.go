@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUnsupportedPeriod возвращается, когда правило повторения задано с
+// периодом, который планировщик не умеет интерпретировать. Полноценные
+// cron-выражения не поддерживаются — только именованные периоды ниже.
+var ErrUnsupportedPeriod = errors.New("период не поддерживается планировщиком")
+
+// RecurringRule описывает правило материализации повторяющейся транзакции:
+// шаблон проводок Template применяется по расписанию Period, отсчитываемому
+// от Start, и не позже End (нулевое значение — бессрочно). LastFired — момент
+// последнего успешно материализованного срабатывания; при регистрации он
+// равен Start, так что первое срабатывание приходится на Start плюс один
+// Period. LastFired персистится, чтобы после перезапуска процесса не
+// потерять и не задвоить начисления.
+type RecurringRule struct {
+	ID        int
+	Template  Transaction
+	Period    string
+	Start     time.Time
+	End       time.Time
+	LastFired time.Time
+}
+
+func isSupportedPeriod(period string) bool {
+	switch period {
+	case "daily", "weekly", "monthly":
+		return true
+	default:
+		return false
+	}
+}
+
+// nextOccurrence возвращает следующий момент срабатывания правила после after.
+func nextOccurrence(period string, after time.Time) time.Time {
+	switch period {
+	case "daily":
+		return after.AddDate(0, 0, 1)
+	case "weekly":
+		return after.AddDate(0, 0, 7)
+	case "monthly":
+		return after.AddDate(0, 1, 0)
+	default:
+		return after
+	}
+}
+
+// ScheduleRecurring регистрирует новое правило повторяющейся транзакции и
+// возвращает присвоенный ID.
+func (l *Ledger) ScheduleRecurring(rule RecurringRule) (int, error) {
+	if len(rule.Template.Postings) == 0 {
+		return 0, ErrEmptyPostings
+	}
+	if !isSupportedPeriod(rule.Period) {
+		return 0, fmt.Errorf("%w: %q", ErrUnsupportedPeriod, rule.Period)
+	}
+	if rule.Start.IsZero() {
+		rule.Start = time.Now()
+	}
+	if rule.LastFired.IsZero() {
+		rule.LastFired = rule.Start
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.nextRecurringID++
+	rule.ID = l.nextRecurringID
+
+	if l.wal != nil {
+		if err := l.wal.append(walRecord{Type: walRecordRecurring, Recurring: &rule}); err != nil {
+			return 0, fmt.Errorf("не удалось записать правило повторения в журнал: %w", err)
+		}
+	}
+
+	l.recurring = append(l.recurring, rule)
+	return rule.ID, nil
+}
+
+// ListRecurring возвращает копию всех зарегистрированных правил повторения.
+func (l *Ledger) ListRecurring() []RecurringRule {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	result := make([]RecurringRule, len(l.recurring))
+	copy(result, l.recurring)
+	return result
+}
+
+// RunScheduler периодически (раз в tick) проверяет правила повторения и
+// материализует просроченные срабатывания через AddTransaction — включая
+// те, что были пропущены, пока процесс не работал, благодаря персистентному
+// LastFired. Возвращается, когда ctx отменяется.
+func (l *Ledger) RunScheduler(ctx context.Context, tick time.Duration) {
+	l.fireDueRecurring(time.Now())
+
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			l.fireDueRecurring(now)
+		}
+	}
+}
+
+// fireDueRecurring материализует все срабатывания правил повторения,
+// наступившие к моменту now (включая пропущенные за время простоя).
+func (l *Ledger) fireDueRecurring(now time.Time) {
+	for _, rule := range l.ListRecurring() {
+		if now.Before(rule.Start) {
+			continue
+		}
+
+		occurrence := nextOccurrence(rule.Period, rule.LastFired)
+		for !occurrence.After(now) && (rule.End.IsZero() || !occurrence.After(rule.End)) {
+			tx := rule.Template
+			tx.Date = occurrence
+			if _, err := l.AddTransaction(tx); err != nil {
+				// Бюджет все еще превышен (или счет недостаточен) — пробуем
+				// это же срабатывание заново на следующем тике.
+				break
+			}
+			rule.LastFired = occurrence
+			occurrence = nextOccurrence(rule.Period, rule.LastFired)
+		}
+
+		l.updateRecurringLastFired(rule)
+	}
+}
+
+// updateRecurringLastFired персистит и применяет новое значение LastFired
+// для правила rule, найденного по rule.ID.
+func (l *Ledger) updateRecurringLastFired(rule RecurringRule) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := range l.recurring {
+		if l.recurring[i].ID != rule.ID {
+			continue
+		}
+		if l.recurring[i].LastFired.Equal(rule.LastFired) {
+			return
+		}
+		if l.wal != nil {
+			if err := l.wal.append(walRecord{Type: walRecordRecurring, Recurring: &rule}); err != nil {
+				return
+			}
+		}
+		l.recurring[i] = rule
+		return
+	}
+}
@@ -0,0 +1,70 @@
+// Package validation собирает валидаторы входных данных, общие для всех
+// точек входа учетной книги (AddTransaction, SetBudget, LoadBudgets, HTTP
+// API). Каждый валидатор возвращает ошибку, оборачивающую один из
+// пакетных сентинелов, чтобы вызывающий код мог использовать errors.Is.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidCategory    = errors.New("недопустимое имя категории")
+	ErrInvalidDescription = errors.New("недопустимое описание")
+	ErrInvalidAmount      = errors.New("недопустимая сумма")
+)
+
+const (
+	maxCategoryLength    = 64
+	maxDescriptionLength = 255
+)
+
+// categoryPattern допускает буквы любого алфавита, цифры, пробелы, дефисы
+// и подчеркивания — этого достаточно для имен категорий вида "Еда" или
+// "Food and Drinks".
+var categoryPattern = regexp.MustCompile(`^[\p{L}\p{N} _-]+$`)
+
+// Category проверяет имя категории бюджета: непустое, без недопустимых
+// символов, не длиннее maxCategoryLength.
+func Category(name string) error {
+	trimmed := strings.TrimSpace(name)
+	if trimmed == "" {
+		return fmt.Errorf("%w: категория не может быть пустой", ErrInvalidCategory)
+	}
+	if utf8.RuneCountInString(trimmed) > maxCategoryLength {
+		return fmt.Errorf("%w: категория длиннее %d символов", ErrInvalidCategory, maxCategoryLength)
+	}
+	if !categoryPattern.MatchString(trimmed) {
+		return fmt.Errorf("%w: категория %q содержит недопустимые символы", ErrInvalidCategory, name)
+	}
+	return nil
+}
+
+// Description проверяет, что описание транзакции не превышает допустимую длину.
+func Description(desc string) error {
+	if utf8.RuneCountInString(desc) > maxDescriptionLength {
+		return fmt.Errorf("%w: описание длиннее %d символов", ErrInvalidDescription, maxDescriptionLength)
+	}
+	return nil
+}
+
+// AmountMinorUnits проверяет, что major (сумма в основных единицах, например
+// рублях) выражена с точностью не более двух знаков после запятой — без
+// дрейфа, характерного для float64 — и возвращает ее в минорных единицах
+// (копейках, центах).
+func AmountMinorUnits(major float64) (int64, error) {
+	if math.IsNaN(major) || math.IsInf(major, 0) {
+		return 0, fmt.Errorf("%w: сумма должна быть конечным числом", ErrInvalidAmount)
+	}
+
+	minor := math.Round(major * 100)
+	if math.Abs(major*100-minor) > 1e-6 {
+		return 0, fmt.Errorf("%w: сумма %.10f содержит больше двух знаков после запятой", ErrInvalidAmount, major)
+	}
+	return int64(minor), nil
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// walRecordType различает виды записей в журнале.
+type walRecordType string
+
+const (
+	walRecordTx        walRecordType = "tx"
+	walRecordBudget    walRecordType = "budget"
+	walRecordRecurring walRecordType = "recurring"
+)
+
+// walRecord — одна запись журнала: новая транзакция, новый/обновленный
+// бюджет или правило повторения (включая обновление его LastFired).
+// Записи сериализуются в JSON с префиксом длины.
+type walRecord struct {
+	Type      walRecordType  `json:"type"`
+	Tx        *Transaction   `json:"tx,omitempty"`
+	Budget    *Budget        `json:"budget,omitempty"`
+	Recurring *RecurringRule `json:"recurring,omitempty"`
+}
+
+// snapshot — полный слепок состояния Ledger, который пишется на диск
+// командой Compact, чтобы журнал не рос бесконечно.
+type snapshot struct {
+	Transactions    []Transaction                `json:"transactions"`
+	Budgets         map[string]Budget            `json:"budgets"`
+	Balances        map[string]map[string]int64  `json:"balances"`
+	Recurring       []RecurringRule              `json:"recurring"`
+	NextID          int                          `json:"next_id"`
+	NextRecurringID int                          `json:"next_recurring_id"`
+}
+
+// walWriter пишет записи журнала в конец файла и сбрасывает их на диск
+// перед тем, как считать запись подтвержденной.
+type walWriter struct {
+	file *os.File
+}
+
+func (w *walWriter) append(rec walRecord) error {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(payload)))
+
+	if _, err := w.file.Write(length[:]); err != nil {
+		return err
+	}
+	if _, err := w.file.Write(payload); err != nil {
+		return err
+	}
+	return w.file.Sync()
+}
+
+func (w *walWriter) Close() error {
+	if w == nil || w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
+
+// readWALRecords последовательно считывает все записи из r. Усеченная
+// (не до конца дописанная) последняя запись не является ошибкой — она
+// означает, что процесс упал посреди записи, и просто отбрасывается.
+func readWALRecords(r io.Reader) ([]walRecord, error) {
+	var records []walRecord
+	for {
+		var length [4]byte
+		if _, err := io.ReadFull(r, length[:]); err != nil {
+			if err == io.EOF {
+				break
+			}
+			// Неполный префикс длины — хвост журнала оборван аварийным
+			// завершением процесса, восстановление останавливается здесь.
+			break
+		}
+
+		size := binary.BigEndian.Uint32(length[:])
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			break
+		}
+
+		var rec walRecord
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			break
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func snapshotPathFor(path string) string { return path + ".snapshot" }
+func walPathFor(path string) string      { return path + ".wal" }
+
+// Reopen открывает (или создает) учетную книгу по базовому пути path.
+// Состояние восстанавливается из последнего снимка (path.snapshot), затем
+// поверх него реплеятся оставшиеся записи журнала (path.wal). После
+// восстановления журнал открывается на дозапись для последующих мутаций.
+func Reopen(path string) (*Ledger, error) {
+	l := &Ledger{
+		budgets:      make(map[string]Budget),
+		balances:     make(map[string]map[string]int64),
+		rates:        NewCachingRatesProvider(NewStaticRatesProvider()),
+		walPath:      walPathFor(path),
+		snapshotPath: snapshotPathFor(path),
+	}
+
+	if snap, err := loadSnapshot(l.snapshotPath); err != nil {
+		return nil, fmt.Errorf("не удалось загрузить снимок %s: %w", l.snapshotPath, err)
+	} else if snap != nil {
+		l.transactions = snap.Transactions
+		l.budgets = snap.Budgets
+		l.balances = snap.Balances
+		l.recurring = snap.Recurring
+		l.nextID = snap.NextID
+		l.nextRecurringID = snap.NextRecurringID
+	}
+
+	if err := l.replayWAL(); err != nil {
+		return nil, fmt.Errorf("не удалось восстановиться из журнала %s: %w", l.walPath, err)
+	}
+
+	walFile, err := os.OpenFile(l.walPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("не удалось открыть журнал %s: %w", l.walPath, err)
+	}
+	l.wal = &walWriter{file: walFile}
+
+	return l, nil
+}
+
+func loadSnapshot(path string) (*snapshot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var snap snapshot
+	if err := json.NewDecoder(f).Decode(&snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// replayWAL реплеит хвост журнала поверх уже загруженного снимка.
+func (l *Ledger) replayWAL() error {
+	f, err := os.Open(l.walPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	records, err := readWALRecords(f)
+	if err != nil {
+		return err
+	}
+
+	for _, rec := range records {
+		switch rec.Type {
+		case walRecordTx:
+			if rec.Tx == nil {
+				continue
+			}
+			// Запись уже была провалидирована до падения процесса — здесь
+			// мы только пересчитываем проекцию балансов и применяем ее.
+			projected, err := l.validateTransaction(*rec.Tx)
+			if err != nil {
+				return fmt.Errorf("повреждена запись транзакции в журнале: %w", err)
+			}
+			l.applyTransaction(*rec.Tx, projected)
+		case walRecordBudget:
+			if rec.Budget == nil {
+				continue
+			}
+			l.budgets[rec.Budget.Category] = *rec.Budget
+		case walRecordRecurring:
+			if rec.Recurring == nil {
+				continue
+			}
+			l.upsertRecurringLocked(*rec.Recurring)
+		}
+	}
+	return nil
+}
+
+// upsertRecurringLocked заменяет правило с тем же ID (или добавляет новое)
+// и продвигает nextRecurringID. Вызывающая сторона отвечает за блокировку.
+func (l *Ledger) upsertRecurringLocked(rule RecurringRule) {
+	for i := range l.recurring {
+		if l.recurring[i].ID == rule.ID {
+			l.recurring[i] = rule
+			if rule.ID > l.nextRecurringID {
+				l.nextRecurringID = rule.ID
+			}
+			return
+		}
+	}
+	l.recurring = append(l.recurring, rule)
+	if rule.ID > l.nextRecurringID {
+		l.nextRecurringID = rule.ID
+	}
+}
+
+// Compact сбрасывает текущее состояние Ledger в новый снимок на диске и
+// усекает журнал, чтобы он не рос неограниченно.
+func (l *Ledger) Compact() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	snap := snapshot{
+		Transactions:    l.transactions,
+		Budgets:         l.budgets,
+		Balances:        l.balances,
+		Recurring:       l.recurring,
+		NextID:          l.nextID,
+		NextRecurringID: l.nextRecurringID,
+	}
+
+	tmpPath := l.snapshotPath + ".tmp"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("не удалось создать временный снимок: %w", err)
+	}
+	if err := json.NewEncoder(tmp).Encode(snap); err != nil {
+		tmp.Close()
+		return fmt.Errorf("не удалось записать снимок: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, l.snapshotPath); err != nil {
+		return fmt.Errorf("не удалось переименовать снимок: %w", err)
+	}
+
+	if err := l.wal.Close(); err != nil {
+		return err
+	}
+	walFile, err := os.OpenFile(l.walPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("не удалось усечь журнал: %w", err)
+	}
+	l.wal = &walWriter{file: walFile}
+
+	return nil
+}
+
+// Close закрывает файл журнала, не выполняя компактизацию.
+func (l *Ledger) Close() error {
+	return l.wal.Close()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestReopenRecoversFromTruncatedWAL simulates a process dying mid-write: a
+// well-formed record is appended to the WAL but only half its payload makes
+// it to disk before the "crash". Reopen must silently drop that truncated
+// tail, recover exactly the transactions committed before it, and leave the
+// ledger writable afterward.
+func TestReopenRecoversFromTruncatedWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger_data")
+
+	l, err := Reopen(path)
+	if err != nil {
+		t.Fatalf("не удалось открыть учетную книгу: %v", err)
+	}
+
+	if err := l.SetBudget(Budget{
+		Category: "Еда",
+		Limit:    Money{Minor: 100000, Asset: defaultAsset},
+		Period:   "monthly",
+	}); err != nil {
+		t.Fatalf("не удалось установить бюджет: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := l.AddTransaction(Transaction{
+			Postings: []Posting{
+				{Source: WorldAccount, Destination: "Еда", Amount: 1000, Asset: defaultAsset},
+			},
+			Description: "транзакция до сбоя",
+		}); err != nil {
+			t.Fatalf("не удалось добавить транзакцию: %v", err)
+		}
+	}
+
+	if err := l.Close(); err != nil {
+		t.Fatalf("не удалось закрыть журнал: %v", err)
+	}
+
+	// Append a record's length prefix and only half its payload, as if the
+	// process crashed mid-fsync of the next write.
+	rec := walRecord{Type: walRecordTx, Tx: &Transaction{
+		Postings: []Posting{
+			{Source: WorldAccount, Destination: "Еда", Amount: 5000, Asset: defaultAsset},
+		},
+		Description: "погибшая при записи транзакция",
+	}}
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatalf("не удалось сериализовать запись: %v", err)
+	}
+
+	walFile, err := os.OpenFile(walPathFor(path), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatalf("не удалось открыть журнал для симуляции сбоя: %v", err)
+	}
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(payload)))
+	if _, err := walFile.Write(length[:]); err != nil {
+		t.Fatalf("не удалось записать префикс длины: %v", err)
+	}
+	if _, err := walFile.Write(payload[:len(payload)/2]); err != nil {
+		t.Fatalf("не удалось записать усеченный payload: %v", err)
+	}
+	if err := walFile.Close(); err != nil {
+		t.Fatalf("не удалось закрыть журнал после симуляции сбоя: %v", err)
+	}
+
+	recovered, err := Reopen(path)
+	if err != nil {
+		t.Fatalf("восстановление после усеченного журнала не удалось: %v", err)
+	}
+	defer recovered.Close()
+
+	txs := recovered.ListTransactions()
+	if len(txs) != 3 {
+		t.Fatalf("ожидалось 3 восстановленные транзакции, получено %d", len(txs))
+	}
+
+	total, err := recovered.GetCategoryTotalIn("Еда", defaultAsset)
+	if err != nil {
+		t.Fatalf("не удалось получить сумму по категории: %v", err)
+	}
+	if total != 3000 {
+		t.Fatalf("сумма по категории 'Еда' после восстановления = %d, ожидалось 3000 (усеченная транзакция не должна была примениться)", total)
+	}
+
+	if _, err := recovered.AddTransaction(Transaction{
+		Postings: []Posting{
+			{Source: WorldAccount, Destination: "Еда", Amount: 1000, Asset: defaultAsset},
+		},
+		Description: "транзакция после восстановления",
+	}); err != nil {
+		t.Fatalf("журнал должен быть доступен для записи после восстановления: %v", err)
+	}
+}